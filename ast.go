@@ -0,0 +1,104 @@
+package peg
+
+// Node is a node of the AST that Parser.ParseAST builds automatically from
+// a grammar, without the caller having to hand-write an Action for every
+// rule. One Node is produced per named rule reference that isn't annotated
+// <no-ast>; unnamed sub-expressions (prioritizedChoice, sequence, etc.) and
+// Ign()-wrapped content don't get a Node of their own; any Nodes nested
+// inside them are attached directly to the nearest enclosing rule's Node.
+type Node interface {
+	// Pos returns the 1-based line and column of the node's start in the
+	// original input, as computed by lineInfo.
+	Pos() (line, col int)
+
+	// Rule returns the name of the grammar rule that produced this node.
+	Rule() string
+
+	// Text returns the substring of the input this node matched.
+	Text() string
+
+	// Children returns the node's nested named-rule nodes, in the order
+	// they matched.
+	Children() []Node
+}
+
+type astNode struct {
+	rule     string
+	line     int
+	col      int
+	text     string
+	children []Node
+}
+
+func (n *astNode) Pos() (int, int)  { return n.line, n.col }
+func (n *astNode) Rule() string     { return n.rule }
+func (n *astNode) Text() string     { return n.text }
+func (n *astNode) Children() []Node { return n.children }
+
+// WalkAST visits root and its descendants depth-first, calling visit on
+// each node. If visit returns false, that node's children are skipped.
+func WalkAST(root Node, visit func(Node) bool) {
+	if root == nil {
+		return
+	}
+	if !visit(root) {
+		return
+	}
+	for _, child := range root.Children() {
+		WalkAST(child, visit)
+	}
+}
+
+// ParseAST parses input against the grammar's start rule and returns the
+// automatically-constructed AST instead of relying on hand-written Actions.
+// It's orthogonal to Parser.Parse/SemanticValues: the two can be used side
+// by side, since building the tree only reads rule names, positions and
+// matched text, the same data Actions already have access to via Values.
+func (p *Parser) ParseAST(input string) (Node, *Error) {
+	v := &Values{}
+	c := &context{
+		s:             input,
+		whitespaceOpe: p.whitespaceOpe,
+		wordOpe:       p.wordOpe,
+		buildAST:      true,
+		astStack:      [][]Node{nil},
+	}
+
+	l, err := p.start.parse(input, 0, v, c, nil)
+	if err != nil {
+		return nil, p.toError(err, input)
+	}
+	if l < len(input) {
+		line, col := lineInfo(input, l)
+		return nil, p.toError(OperatorError{
+			Expected: []string{"EOF"},
+			Got:      input,
+			Line:     line,
+			Col:      col,
+			Length:   len(input) - l,
+		}, input)
+	}
+
+	line, col := lineInfo(input, 0)
+	return buildASTRoot(c.astStack[0], p.start.Name, line, col, input[:l]), nil
+}
+
+// buildASTRoot turns the top-level nodes collected under the start rule
+// into the single Node that ParseAST returns. A start rule whose body is a
+// single named reference already produces the natural root, so that node
+// is returned as-is. Anything else - several top-level named references
+// (e.g. Start <- Digit Digit) or none at all (e.g. Start <- [0-9]+) - is
+// wrapped in a synthetic root for the start rule itself, so no sibling is
+// silently dropped and a successful parse never hands back a nil Node.
+func buildASTRoot(roots []Node, rule string, line, col int, text string) Node {
+	if len(roots) == 1 {
+		return roots[0]
+	}
+	return &astNode{
+		rule:     rule,
+		line:     line,
+		col:      col,
+		text:     text,
+		children: roots,
+	}
+}
@@ -0,0 +1,92 @@
+package peg
+
+import "testing"
+
+type fakeNode struct {
+	rule     string
+	text     string
+	children []Node
+}
+
+func (n *fakeNode) Pos() (int, int)  { return 1, 1 }
+func (n *fakeNode) Rule() string     { return n.rule }
+func (n *fakeNode) Text() string     { return n.text }
+func (n *fakeNode) Children() []Node { return n.children }
+
+func TestWalkAST(t *testing.T) {
+	leaf1 := &fakeNode{rule: "Digit", text: "1"}
+	leaf2 := &fakeNode{rule: "Digit", text: "2"}
+	root := &fakeNode{rule: "Sum", text: "1+2", children: []Node{leaf1, leaf2}}
+
+	var visited []string
+	WalkAST(root, func(n Node) bool {
+		visited = append(visited, n.Rule()+":"+n.Text())
+		return true
+	})
+
+	want := []string{"Sum:1+2", "Digit:1", "Digit:2"}
+	if len(visited) != len(want) {
+		t.Fatalf("[WalkAST] want:%v got:%v", want, visited)
+	}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Errorf("[WalkAST] at %d want:%q got:%q", i, want[i], visited[i])
+		}
+	}
+}
+
+func TestWalkASTStopsDescent(t *testing.T) {
+	leaf := &fakeNode{rule: "Digit", text: "1"}
+	root := &fakeNode{rule: "Sum", text: "1", children: []Node{leaf}}
+
+	var visited []string
+	WalkAST(root, func(n Node) bool {
+		visited = append(visited, n.Rule())
+		return false
+	})
+
+	if len(visited) != 1 || visited[0] != "Sum" {
+		t.Errorf("[WalkAST] want descent stopped after root, got:%v", visited)
+	}
+}
+
+func TestBuildASTRootSingleChildIsReturnedAsIs(t *testing.T) {
+	only := &fakeNode{rule: "Digit", text: "1"}
+	got := buildASTRoot([]Node{only}, "Start", 1, 1, "1")
+	if got != Node(only) {
+		t.Errorf("[BuildASTRoot] want the single top-level node returned unwrapped, got:%v", got)
+	}
+}
+
+func TestBuildASTRootWrapsMultipleSiblingsInsteadOfDroppingThem(t *testing.T) {
+	// Regression test: Start <- Digit Digit over "12" must keep both
+	// digits, not just the last one.
+	first := &fakeNode{rule: "Digit", text: "1"}
+	second := &fakeNode{rule: "Digit", text: "2"}
+
+	root := buildASTRoot([]Node{first, second}, "Start", 1, 1, "12")
+	if root == nil {
+		t.Fatal("[BuildASTRoot] want a synthetic root, got nil")
+	}
+	if root.Rule() != "Start" || root.Text() != "12" {
+		t.Errorf("[BuildASTRoot] want synthetic root for Start/\"12\", got rule:%q text:%q", root.Rule(), root.Text())
+	}
+	if len(root.Children()) != 2 || root.Children()[0] != Node(first) || root.Children()[1] != Node(second) {
+		t.Errorf("[BuildASTRoot] want both siblings kept as children, got:%v", root.Children())
+	}
+}
+
+func TestBuildASTRootSynthesizesNonNilRootWithNoNamedChildren(t *testing.T) {
+	// Regression test: Start <- [0-9]+ never references another named
+	// rule, so there are no top-level nodes to pick from - ParseAST must
+	// still return a non-nil root instead of (nil, nil), which a caller
+	// can't distinguish from "something went wrong".
+	root := buildASTRoot(nil, "Start", 1, 1, "123")
+	if root == nil {
+		t.Fatal("[BuildASTRoot] want a synthetic root even with no named children, got nil")
+	}
+	if root.Rule() != "Start" || root.Text() != "123" || len(root.Children()) != 0 {
+		t.Errorf("[BuildASTRoot] want empty-children root for Start/\"123\", got rule:%q text:%q children:%v",
+			root.Rule(), root.Text(), root.Children())
+	}
+}
@@ -0,0 +1,88 @@
+package peg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// mergeExpectations collapses OperatorErrors that occurred at the same
+// line/column into a single entry with a combined, deduplicated Expected
+// list, so a caller sees one "expected one of ..." message per position
+// instead of the full SequenceError tree that produced them.
+func mergeExpectations(errs []OperatorError) []OperatorError {
+	type pos struct{ line, col int }
+
+	var order []pos
+	merged := map[pos]*OperatorError{}
+
+	for _, e := range errs {
+		key := pos{e.Line, e.Col}
+		m, ok := merged[key]
+		if !ok {
+			cp := e
+			cp.Expected = append([]string{}, e.Expected...)
+			merged[key] = &cp
+			order = append(order, key)
+			continue
+		}
+		for _, exp := range e.Expected {
+			if !containsString(m.Expected, exp) {
+				m.Expected = append(m.Expected, exp)
+			}
+		}
+		if e.Length > m.Length {
+			m.Length = e.Length
+		}
+	}
+
+	out := make([]OperatorError, 0, len(order))
+	for _, key := range order {
+		out = append(out, *merged[key])
+	}
+	return out
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Format writes a pigeon-style excerpt for every error in e.Details to w:
+// "line:col", the offending source line, and a caret span sized by
+// OperatorError.Length. Errors that share a position are merged first, so
+// a sequence that tried several alternatives at the same spot reports one
+// "expected one of ..." instead of one line per alternative.
+func (e *Error) Format(w io.Writer, src string) {
+	for _, oe := range mergeExpectations(e.Details) {
+		lineStart, lineEnd := printLine(src, oe.Line)
+		prefix := fmt.Sprintf("%d:%d: ", oe.Line, oe.Col)
+		fmt.Fprintf(w, "%s%s\n", prefix, src[lineStart:lineEnd])
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat(" ", len(prefix)+oe.Col-1), strings.Repeat("^", maxInt(oe.Length, 1)))
+
+		endOfToken := lineStart + (oe.Col - 1) + oe.Length
+		if endOfToken > len(oe.Got) {
+			endOfToken = len(oe.Got)
+		}
+		got := oe.Got[lineStart+(oe.Col-1) : endOfToken]
+
+		if len(oe.Expected) > 1 {
+			fmt.Fprintf(w, "expected one of \"%s\", got %q\n\n", strings.Join(oe.Expected, "\", \""), got)
+		} else if len(oe.Expected) == 1 {
+			fmt.Fprintf(w, "expected %q, got %q\n\n", oe.Expected[0], got)
+		} else {
+			fmt.Fprintf(w, "got %q\n\n", got)
+		}
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
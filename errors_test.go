@@ -0,0 +1,96 @@
+package peg
+
+import "testing"
+
+func TestMergeExpectations(t *testing.T) {
+	errs := []OperatorError{
+		{Expected: []string{"a"}, Line: 1, Col: 3, Length: 1},
+		{Expected: []string{"b"}, Line: 1, Col: 3, Length: 1},
+		{Expected: []string{"c"}, Line: 2, Col: 1, Length: 1},
+	}
+
+	merged := mergeExpectations(errs)
+	if len(merged) != 2 {
+		t.Fatalf("[MergeExpectations] want 2 distinct positions, got %d: %v", len(merged), merged)
+	}
+	if len(merged[0].Expected) != 2 || merged[0].Expected[0] != "a" || merged[0].Expected[1] != "b" {
+		t.Errorf("[MergeExpectations] want merged Expected [a b] at 1:3, got:%v", merged[0].Expected)
+	}
+	if len(merged[1].Expected) != 1 || merged[1].Expected[0] != "c" {
+		t.Errorf("[MergeExpectations] want Expected [c] at 2:1, got:%v", merged[1].Expected)
+	}
+}
+
+func TestErrorRecoverySkipsToSync(t *testing.T) {
+	// Rec(Lit("a"), Lit(";")) against "xxx;tail": "a" never matches, so the
+	// recovery records the failure and skips to the next ';', reporting
+	// success up to and including it.
+	ope := Rec(Lit("a"), Lit(";"))
+
+	c := &context{}
+	v := &Values{}
+	l, err := ope.parseCore("xxx;tail", 0, v, c, nil)
+	if err != nil {
+		t.Fatalf("[ErrorRecovery] want success after recovery, got err:%v", err)
+	}
+	if want := len("xxx;"); l != want {
+		t.Errorf("[ErrorRecovery] want skip length:%d got:%d", want, l)
+	}
+	if len(c.errors) == 0 {
+		t.Errorf("[ErrorRecovery] want the original failure recorded in c.errors")
+	}
+}
+
+func TestErrorRecoveryClampsToEOFWithoutSync(t *testing.T) {
+	// No ';' anywhere in the input: recovery must stop at EOF, not one past
+	// it, since whatever called this operator will go on to slice s[p:].
+	ope := Rec(Lit("a"), Lit(";"))
+
+	c := &context{}
+	v := &Values{}
+	input := "xxxxx"
+	l, err := ope.parseCore(input, 0, v, c, nil)
+	if err != nil {
+		t.Fatalf("[ErrorRecovery] want success after recovery, got err:%v", err)
+	}
+	if l != len(input) {
+		t.Errorf("[ErrorRecovery] want skip clamped to input length:%d got:%d", len(input), l)
+	}
+}
+
+func TestErrorRecoveryPassesThroughSuccess(t *testing.T) {
+	ope := Rec(Lit("a"), Lit(";"))
+
+	c := &context{}
+	v := &Values{}
+	l, err := ope.parseCore("a;tail", 0, v, c, nil)
+	if err != nil || l != 1 {
+		t.Errorf("[ErrorRecovery] want a clean match to pass through unchanged, got l:%d err:%v", l, err)
+	}
+	if len(c.errors) != 0 {
+		t.Errorf("[ErrorRecovery] want no recorded errors on success, got:%v", c.errors)
+	}
+}
+
+func TestErrorRecoveryCollectsAcrossMultipleScopes(t *testing.T) {
+	// Two independent Rec() scopes in one sequence, each recovering from a
+	// distinct bad statement, both contribute to the same c.errors - this
+	// is the one-pass, multiple-errors-collected behavior the recovery
+	// operator exists for. (Parser.Parse itself, which would drive this
+	// over a real grammar end to end, lives outside this tree's snapshot.)
+	stmt := Rec(Lit("a"), Lit(";"))
+	ope := Seq(stmt, stmt)
+
+	c := &context{}
+	v := &Values{}
+	l, err := ope.parseCore("xx;yy;", 0, v, c, nil)
+	if err != nil {
+		t.Fatalf("[ErrorRecovery] want the sequence to succeed via recovery, got err:%v", err)
+	}
+	if want := len("xx;yy;"); l != want {
+		t.Errorf("[ErrorRecovery] want:%d got:%d", want, l)
+	}
+	if len(c.errors) != 2 {
+		t.Fatalf("[ErrorRecovery] want 2 recovered errors, got %d: %v", len(c.errors), c.errors)
+	}
+}
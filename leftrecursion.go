@@ -0,0 +1,184 @@
+package peg
+
+import "errors"
+
+// Left-recursion support, implementing the seed-growing algorithm from
+// Warth, Douglass & Millstein, "Packrat Parsers Can Support Left
+// Recursion". With Parser.EnableLeftRecursion(true), reference.parseCore
+// routes every named-rule definition through parseWithSeedGrowing instead
+// of calling rule.parse directly (see ope.go), so grammars with direct left
+// recursion (E <- E '+' T / T) or indirect left recursion (A <- B 'x' / 'a';
+// B <- A 'y' / 'b') terminate with the longest possible match instead of
+// infinitely recursing. Only rule applications that are actually
+// left-recursive pay for the grow loop: parseWithSeedGrowing detects this by
+// running the body once and checking whether anything re-entered the same
+// (ruleID, pos) along the way, so an ordinary, non-recursive rule still
+// costs exactly one parse with the feature turned on.
+
+// lrKey identifies a rule application at a given input position.
+type lrKey struct {
+	ruleID int
+	pos    int
+}
+
+// seed is the current best result of a left-recursive rule application.
+// Seed-growing replaces it with longer and longer matches until the body
+// stops consuming more input, at which point the last seed wins.
+type seed struct {
+	length int
+	err    error
+	values []Any
+	tokens []Token
+}
+
+// head tracks one seed-growing session, including which other rules were
+// found to call back into this position (indirect left recursion), so
+// their packrat memo entries can be invalidated as the seed grows.
+type head struct {
+	ruleID   int
+	involved map[int]bool
+	// recursed is set the moment something re-enters this exact (ruleID,
+	// pos) while the first pass is still running. Most rule applications
+	// never do this - they aren't left-recursive at all - and for those,
+	// growing a seed would just reparse the same, unchanging result over
+	// and over. Only a head that actually saw a recursed call needs the
+	// grow loop below.
+	recursed bool
+}
+
+var errNoSeedYet = errors.New("left recursion: no seed to return yet")
+
+// parseWithSeedGrowing implements seed-growing for a single rule
+// application. parseBody must invoke the rule's own operator tree exactly
+// as Rule.parse would without left-recursion support; it may be called
+// more than once, at the same position, as the seed grows.
+func parseWithSeedGrowing(ruleID int, p int, v *Values, c *context, parseBody func(v *Values, c *context) (int, error)) (int, error) {
+	key := lrKey{ruleID, p}
+
+	// Re-entrant call while a seed is already growing for this rule at
+	// this position: hand back whatever the current seed is (a failure,
+	// the first time around). Every other rule on the active call chain is
+	// marked as involved, callers above the head included, not only those
+	// between the head and here (e.g. B, in A -> B -> A), so their packrat
+	// memo entries at p are invalidated as the seed grows too.
+	if h, ok := c.heads[key]; ok {
+		h.recursed = true
+		for _, id := range c.activeChain {
+			if id != ruleID {
+				h.involved[id] = true
+			}
+		}
+		if sd := c.lrSeeds[key]; sd != nil {
+			v.Vs = append(v.Vs, sd.values...)
+			v.Ts = append(v.Ts, sd.tokens...)
+			return sd.length, sd.err
+		}
+		return 0, errNoSeedYet
+	}
+
+	if c.heads == nil {
+		c.heads = map[lrKey]*head{}
+	}
+	if c.lrSeeds == nil {
+		c.lrSeeds = map[lrKey]*seed{}
+	}
+
+	h := &head{ruleID: ruleID, involved: map[int]bool{}}
+	c.heads[key] = h
+	c.lrSeeds[key] = &seed{err: errNoSeedYet}
+
+	c.activeChain = append(c.activeChain, ruleID)
+
+	// First pass. Whether this call is left-recursive at all can only be
+	// known after running it once: if nothing re-enters key along the way,
+	// h.recursed stays false and below we skip straight to returning this
+	// result, at the same one-call cost as without left-recursion support.
+	saveVsLen := len(v.Vs)
+	saveTsLen := len(v.Ts)
+	l, err := parseBody(v, c)
+
+	if !h.recursed {
+		c.activeChain = c.activeChain[:len(c.activeChain)-1]
+		delete(c.heads, key)
+		delete(c.lrSeeds, key)
+		return l, err
+	}
+
+	var best seed
+	grew := false
+	if err == nil {
+		best = seed{
+			length: l,
+			values: append([]Any{}, v.Vs[saveVsLen:]...),
+			tokens: append([]Token{}, v.Ts[saveTsLen:]...),
+		}
+		grew = true
+		c.lrSeeds[key] = &best
+		invalidateInvolvedMemo(c, h, p)
+	}
+	v.Vs = v.Vs[:saveVsLen]
+	v.Ts = v.Ts[:saveTsLen]
+
+	for grew {
+		saveVsLen := len(v.Vs)
+		saveTsLen := len(v.Ts)
+
+		l, err := parseBody(v, c)
+
+		if err != nil || l <= best.length {
+			v.Vs = v.Vs[:saveVsLen]
+			v.Ts = v.Ts[:saveTsLen]
+			break
+		}
+
+		best = seed{
+			length: l,
+			values: append([]Any{}, v.Vs[saveVsLen:]...),
+			tokens: append([]Token{}, v.Ts[saveTsLen:]...),
+		}
+		c.lrSeeds[key] = &best
+
+		// The seed grew through indirectly-involved rules too: their memo
+		// entries at this position are now stale and must be recomputed.
+		invalidateInvolvedMemo(c, h, p)
+
+		v.Vs = v.Vs[:saveVsLen]
+		v.Ts = v.Ts[:saveTsLen]
+	}
+
+	c.activeChain = c.activeChain[:len(c.activeChain)-1]
+	delete(c.heads, key)
+	delete(c.lrSeeds, key)
+
+	if !grew {
+		// Left-recursive at this position, but even the base case never
+		// matched: report the original failure from the first pass.
+		return l, err
+	}
+
+	v.Vs = append(v.Vs, best.values...)
+	v.Ts = append(v.Ts, best.tokens...)
+	return best.length, best.err
+}
+
+// invalidateInvolvedMemo drops the packrat memo entries, at position p, of
+// every rule that called back into h's seed while it was growing. Those
+// results were computed against an earlier, shorter seed and are now stale.
+func invalidateInvolvedMemo(c *context, h *head, p int) {
+	if c.memo == nil {
+		return
+	}
+	for involvedID := range h.involved {
+		for mk := range c.memo {
+			if mk.ruleID == involvedID && mk.pos == p {
+				delete(c.memo, mk)
+			}
+		}
+	}
+}
+
+// EnableLeftRecursion turns Warth-style left-recursion support on or off
+// for subsequent calls to Parse.
+func (p *Parser) EnableLeftRecursion(enable bool) {
+	p.leftRecursion = enable
+}
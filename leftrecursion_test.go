@@ -0,0 +1,206 @@
+package peg
+
+import "testing"
+
+// lrTestRule stands in for a *Rule wired into parseWithSeedGrowing, since
+// this snapshot of the tree doesn't carry the grammar/Rule machinery that
+// normally reaches it through reference.parseCore.
+type lrTestRule struct {
+	opeBase
+	id   int
+	body operator
+}
+
+func (o *lrTestRule) parseCore(s string, p int, v *Values, c *context, d Any) (int, error) {
+	return parseWithSeedGrowing(o.id, p, v, c, func(v *Values, c *context) (int, error) {
+		return o.body.parse(s, p, v, c, d)
+	})
+}
+
+func (o *lrTestRule) accept(v visitor) {}
+
+func newLRTestRule(id int) *lrTestRule {
+	o := &lrTestRule{id: id}
+	o.derived = o
+	return o
+}
+
+func TestLeftRecursionDirect(t *testing.T) {
+	// E <- E '+' T / T
+	// T <- [0-9]
+	e := newLRTestRule(1)
+	tRule := newLRTestRule(2)
+	tRule.body = Cls("0-9")
+	e.body = Cho(
+		Seq(e, Lit("+"), tRule),
+		tRule,
+	)
+
+	cases := Cases{
+		{"1", 1},
+		{"1+2", 3},
+		{"1+2+3", 5},
+	}
+	for _, cs := range cases {
+		c := &context{}
+		v := &Values{}
+		l, err := e.parseCore(cs.input, 0, v, c, nil)
+		if err != nil || l != cs.want {
+			t.Errorf("[LeftRecursionDirect] input:%q want:%d got:%d err:%v", cs.input, cs.want, l, err)
+		}
+	}
+}
+
+func TestLeftRecursionIndirect(t *testing.T) {
+	// A <- B 'x' / 'a'
+	// B <- A 'y' / 'b'
+	a := newLRTestRule(1)
+	b := newLRTestRule(2)
+	a.body = Cho(Seq(b, Lit("x")), Lit("a"))
+	b.body = Cho(Seq(a, Lit("y")), Lit("b"))
+
+	cases := Cases{
+		{"a", 1},
+		{"bx", 2},
+		{"bxyx", 4},
+		{"b", -1},
+		{"byx", -1},
+	}
+	for _, cs := range cases {
+		c := &context{}
+		v := &Values{}
+		l, err := a.parseCore(cs.input, 0, v, c, nil)
+		if cs.want < 0 {
+			if err == nil {
+				t.Errorf("[LeftRecursionIndirect] input:%q want error, got l:%d", cs.input, l)
+			}
+			continue
+		}
+		if err != nil || l != cs.want {
+			t.Errorf("[LeftRecursionIndirect] input:%q want:%d got:%d err:%v", cs.input, cs.want, l, err)
+		}
+	}
+}
+
+// countingOperator wraps another operator and counts how many times it
+// actually runs, so tests can tell a single evaluation apart from the grow
+// loop quietly re-running it.
+type countingOperator struct {
+	opeBase
+	ope   operator
+	count int
+}
+
+func (o *countingOperator) parseCore(s string, p int, v *Values, c *context, d Any) (int, error) {
+	o.count++
+	return o.ope.parse(s, p, v, c, d)
+}
+
+func (o *countingOperator) accept(v visitor) {}
+
+func newCountingOperator(ope operator) *countingOperator {
+	o := &countingOperator{ope: ope}
+	o.derived = o
+	return o
+}
+
+func TestLeftRecursionNonRecursiveChainParsesOnce(t *testing.T) {
+	// Regression test: a 10-rule non-recursive reference chain
+	// (R9 -> R8 -> ... -> R0, no rule referencing itself) must cost the
+	// leaf exactly one parse with EnableLeftRecursion(true), the same as
+	// with it off. parseWithSeedGrowing used to always re-run the body a
+	// second time to check for further growth, and that doubling
+	// multiplied across the chain into 2^depth leaf calls.
+	const depth = 10
+	leaf := newCountingOperator(Cls("0-9"))
+
+	rules := make([]*lrTestRule, depth)
+	for i := range rules {
+		rules[i] = newLRTestRule(i)
+	}
+	rules[0].body = leaf
+	for i := 1; i < depth; i++ {
+		rules[i].body = rules[i-1]
+	}
+
+	c := &context{leftRecursion: true}
+	v := &Values{}
+	l, err := rules[depth-1].parseCore("5", 0, v, c, nil)
+	if err != nil || l != 1 {
+		t.Fatalf("[LeftRecursionNonRecursiveChain] want l:1 err:nil, got l:%d err:%v", l, err)
+	}
+	if leaf.count != 1 {
+		t.Errorf("[LeftRecursionNonRecursiveChain] want the leaf parsed exactly once, got %d calls", leaf.count)
+	}
+}
+
+func TestLeftRecursionSeedGrowthBypassesPackratCache(t *testing.T) {
+	// Regression test for the packrat+left-recursion interaction: a
+	// memoizing body that doesn't know to bypass the cache while its own
+	// seed is growing would store the first, shortest iteration's result
+	// under (ruleID, pos) and then, every later iteration, just hand that
+	// stale result back instead of re-parsing - so the seed would never
+	// grow past its first iteration. E <- E '+' T / T, T <- [0-9] over
+	// "1+2+3" must still reach length 5 with a memoizing body, the same as
+	// TestLeftRecursionDirect gets with a plain one, by skipping the memo
+	// read/write entirely while c.heads still holds this (ruleID, pos).
+	e := newLRTestRule(1)
+	tRule := newLRTestRule(2)
+	tRule.body = Cls("0-9")
+	inner := Cho(Seq(e, Lit("+"), tRule), tRule)
+
+	c := &context{leftRecursion: true, memo: map[memoKey]memoEntry{}}
+	v := &Values{}
+
+	mk := memoKey{ruleID: e.id, pos: 0}
+	body := func(v *Values, c *context) (int, error) {
+		growing := c.heads[lrKey{e.id, 0}] != nil
+		if !growing {
+			if ent, ok := c.memo[mk]; ok {
+				v.Vs = append(v.Vs, ent.values...)
+				v.Ts = append(v.Ts, ent.tokens...)
+				return ent.length, ent.err
+			}
+		}
+		saveVsLen, saveTsLen := len(v.Vs), len(v.Ts)
+		l, err := inner.parse("1+2+3", 0, v, c, nil)
+		if !growing {
+			c.memo[mk] = memoEntry{
+				length: l,
+				err:    err,
+				values: append([]Any{}, v.Vs[saveVsLen:]...),
+				tokens: append([]Token{}, v.Ts[saveTsLen:]...),
+			}
+		}
+		return l, err
+	}
+
+	l, err := parseWithSeedGrowing(e.id, 0, v, c, body)
+	if err != nil || l != 5 {
+		t.Fatalf("[LeftRecursionSeedGrowthBypassesPackratCache] want l:5 err:nil, got l:%d err:%v", l, err)
+	}
+}
+
+func TestLeftRecursionWithAction(t *testing.T) {
+	// Confirms that only the final, winning seed iteration's tokens survive
+	// in v.Ts, the tokens an Action would be handed: each grow attempt
+	// restores v.Ts before the next attempt, and only the last successful
+	// seed's tokens are appended back in.
+	e := newLRTestRule(1)
+	tRule := newLRTestRule(2)
+	tRule.body = Tok(Cls("0-9"))
+	e.body = Cho(
+		Seq(e, Lit("+"), tRule),
+		tRule,
+	)
+
+	c := &context{}
+	v := &Values{}
+	l, err := e.parseCore("1+2+3", 0, v, c, nil)
+	if err != nil || l != 5 {
+		t.Fatalf("[LeftRecursionWithAction] want:5 got:%d err:%v", l, err)
+	}
+	if len(v.Ts) != 3 {
+		t.Errorf("[LeftRecursionWithAction] want 3 tokens from the winning seed, got:%d (%v)", len(v.Ts), v.Ts)
+	}
+}
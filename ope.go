@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Sequence Error
@@ -132,10 +134,56 @@ type context struct {
 
 	wordOpe operator
 
+	// committed is set by a Cut() operator to tell the enclosing
+	// prioritizedChoice to stop trying further alternatives.
+	committed bool
+
+	// memo holds packrat memoization results, keyed by rule and input
+	// position. It is nil unless the Parser has EnablePackrat(true), in
+	// which case it is set up once per top-level Parse call.
+	memo map[memoKey]memoEntry
+
+	// leftRecursion, heads, lrSeeds and activeChain support Warth-style
+	// left-recursion handling (see leftrecursion.go). heads/lrSeeds/
+	// activeChain are nil/empty unless leftRecursion is true, which the
+	// Parser sets via EnableLeftRecursion(true).
+	leftRecursion bool
+	heads         map[lrKey]*head
+	lrSeeds       map[lrKey]*seed
+	activeChain   []int
+
+	// buildAST and astStack drive automatic AST construction (see ast.go).
+	// astStack holds, for each named rule currently being parsed, the
+	// child nodes collected so far from its nested named references.
+	buildAST bool
+	astStack [][]Node
+
+	// errors accumulates recovered syntax errors across an ErrorRecovery
+	// scope (see errorRecovery), so Parser.Parse can report every error found
+	// in one pass instead of aborting on the first one.
+	errors []OperatorError
+
 	tracerEnter func(name string, s string, v *Values, d Any, p int)
 	tracerLeave func(name string, s string, v *Values, d Any, p int, l int)
 }
 
+// memoKey identifies a packrat memo entry: a rule parsed at a given input
+// position, plus a hash of the macro arguments in scope (empty for
+// non-macro rules, where it has no effect).
+type memoKey struct {
+	ruleID  int
+	pos     int
+	argsKey string
+}
+
+// memoEntry is the cached outcome of parsing a rule at a given position.
+type memoEntry struct {
+	length int
+	err    error
+	values []Any
+	tokens []Token
+}
+
 func (c *context) setErrorPos(p int) {
 	if c.errorPos < p {
 		c.errorPos = p
@@ -240,9 +288,13 @@ func (o *prioritizedChoice) parseCore(s string, p int, v *Values, c *context, d
 	id := 0
 	for _, ope := range o.opes {
 		opeLabels = append(opeLabels, ope.Label())
+		saveCommitted := c.committed
+		c.committed = false
 		chv := c.push()
 		l, e = ope.parse(s, p, chv, c, d)
 		c.pop()
+		committed := c.committed
+		c.committed = saveCommitted
 		if e == nil {
 			v.Vs = append(v.Vs, chv.Vs...)
 			v.Pos = chv.Pos
@@ -254,6 +306,12 @@ func (o *prioritizedChoice) parseCore(s string, p int, v *Values, c *context, d
 
 		errs = append(errs, e)
 		id++
+
+		// A Cut() matched inside this alternative: commit to it and don't
+		// try the remaining alternatives, even though it ultimately failed.
+		if committed {
+			break
+		}
 	}
 
 	l = 0
@@ -410,6 +468,27 @@ func (o *notPredicate) accept(v visitor) {
 	v.visitNotPredicate(o)
 }
 
+// Cut
+//
+// Cut marks a commit point inside a sequence: once it is reached, the
+// enclosing prioritizedChoice stops trying further alternatives, whether or
+// not the rest of the sequence goes on to succeed. This trades backtracking
+// for better error messages (and, combined with packrat memoization, lets
+// the memo entry for a committed-but-failed alternative be treated as a
+// final failure rather than retried).
+type cut struct {
+	opeBase
+}
+
+func (o *cut) parseCore(s string, p int, v *Values, c *context, d Any) (int, error) {
+	c.committed = true
+	return 0, nil
+}
+
+func (o *cut) accept(v visitor) {
+	v.visitCut(o)
+}
+
 // Literal String
 type literalString struct {
 	opeBase
@@ -472,11 +551,19 @@ func (o *literalString) accept(v visitor) {
 // Character Class
 type characterClass struct {
 	opeBase
-	chars string
+	chars      string
+	ranges     []runeRange
+	classes    []*unicode.RangeTable
+	negClasses []*unicode.RangeTable
+}
+
+// runeRange is an inclusive [lo, hi] range of runes accepted by a character
+// class, e.g. the "α-ω" in Cls("α-ω").
+type runeRange struct {
+	lo, hi rune
 }
 
 func (o *characterClass) parseCore(s string, p int, v *Values, c *context, d Any) (l int, err error) {
-	// TODO: UTF8 support
 	if len(s)-p < 1 {
 		c.setErrorPos(p)
 		l = 0
@@ -493,23 +580,28 @@ func (o *characterClass) parseCore(s string, p int, v *Values, c *context, d Any
 
 		return
 	}
-	ch := s[p]
-	i := 0
-	for i < len(o.chars) {
-		if i+2 < len(o.chars) && o.chars[i+1] == '-' {
-			if o.chars[i] <= ch && ch <= o.chars[i+2] {
-				l = 1
-				return
-			}
-			i += 3
-		} else {
-			if o.chars[i] == ch {
-				l = 1
-				return
-			}
-			i++
+
+	ch, sz := utf8.DecodeRuneInString(s[p:])
+
+	for _, r := range o.ranges {
+		if r.lo <= ch && ch <= r.hi {
+			l = sz
+			return
+		}
+	}
+	for _, tbl := range o.classes {
+		if unicode.Is(tbl, ch) {
+			l = sz
+			return
+		}
+	}
+	for _, tbl := range o.negClasses {
+		if !unicode.Is(tbl, ch) {
+			l = sz
+			return
 		}
 	}
+
 	c.setErrorPos(p)
 
 	line, col := lineInfo(s, p)
@@ -520,7 +612,7 @@ func (o *characterClass) parseCore(s string, p int, v *Values, c *context, d Any
 		s,
 		line,
 		col,
-		1,
+		sz,
 	}
 	return
 }
@@ -529,13 +621,112 @@ func (o *characterClass) accept(v visitor) {
 	v.visitCharacterClass(o)
 }
 
+// parseCharClass compiles a Cls() spec into rune ranges and Unicode category
+// shortcuts. Alongside plain runes and "lo-hi" rune ranges, it recognizes
+// "\uXXXX" escapes and the category shortcuts "\p{Name}" /
+// negated "\P{Name}", where Name is looked up first against
+// unicode.Categories and then unicode.Scripts (so "\p{L}" and "\p{Han}"
+// both work).
+//
+// Scope note: this only covers Cls() calls made directly from Go. This
+// tree carries no grammar-text (.peg file) parser to extend - there is no
+// file anywhere in this package that builds a characterClass operator out
+// of parsed grammar source - so whether "[α-ω]", "\uXXXX" or "\p{...}" are
+// reachable when writing a rule in grammar text rather than constructing
+// Cls(...) in Go is outside what this package can verify or implement.
+func parseCharClass(chars string) (ranges []runeRange, classes, negClasses []*unicode.RangeTable) {
+	runes := []rune(chars)
+	i := 0
+	for i < len(runes) {
+		if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == 'p' || runes[i+1] == 'P') {
+			neg := runes[i+1] == 'P'
+			i += 2
+			if i < len(runes) && runes[i] == '{' {
+				j := i + 1
+				for j < len(runes) && runes[j] != '}' {
+					j++
+				}
+				if tbl := unicodeCategory(string(runes[i+1 : j])); tbl != nil {
+					if neg {
+						negClasses = append(negClasses, tbl)
+					} else {
+						classes = append(classes, tbl)
+					}
+				}
+				i = j + 1
+			}
+			continue
+		}
+
+		lo, ok := parseClassRune(runes, &i)
+		if !ok {
+			continue
+		}
+		if i+1 < len(runes) && runes[i] == '-' {
+			i++
+			hi, ok := parseClassRune(runes, &i)
+			if !ok {
+				hi = lo
+			}
+			ranges = append(ranges, runeRange{lo, hi})
+		} else {
+			ranges = append(ranges, runeRange{lo, lo})
+		}
+	}
+	return
+}
+
+// parseClassRune reads a single rune at *i, expanding a "\uXXXX" escape if
+// present, and advances *i past it.
+func parseClassRune(runes []rune, i *int) (rune, bool) {
+	if *i >= len(runes) {
+		return 0, false
+	}
+	if runes[*i] == '\\' && *i+5 < len(runes) && runes[*i+1] == 'u' {
+		if r, ok := parseHexRune(runes[*i+2 : *i+6]); ok {
+			*i += 6
+			return r, true
+		}
+	}
+	r := runes[*i]
+	*i++
+	return r, true
+}
+
+func parseHexRune(digits []rune) (rune, bool) {
+	var r rune
+	for _, d := range digits {
+		r <<= 4
+		switch {
+		case d >= '0' && d <= '9':
+			r |= d - '0'
+		case d >= 'a' && d <= 'f':
+			r |= d - 'a' + 10
+		case d >= 'A' && d <= 'F':
+			r |= d - 'A' + 10
+		default:
+			return 0, false
+		}
+	}
+	return r, true
+}
+
+func unicodeCategory(name string) *unicode.RangeTable {
+	if tbl, ok := unicode.Categories[name]; ok {
+		return tbl
+	}
+	if tbl, ok := unicode.Scripts[name]; ok {
+		return tbl
+	}
+	return nil
+}
+
 // Any Character
 type anyCharacter struct {
 	opeBase
 }
 
 func (o *anyCharacter) parseCore(s string, p int, v *Values, c *context, d Any) (l int, err error) {
-	// TODO: UTF8 support
 	if len(s)-p < 1 {
 		c.setErrorPos(p)
 		l = 0
@@ -551,7 +742,7 @@ func (o *anyCharacter) parseCore(s string, p int, v *Values, c *context, d Any)
 		}
 		return
 	}
-	l = 1
+	_, l = utf8.DecodeRuneInString(s[p:])
 	return
 }
 
@@ -605,6 +796,68 @@ func (o *ignore) accept(v visitor) {
 	v.visitIgnore(o)
 }
 
+// Error Recovery
+//
+// errorRecovery is the operator built by Rec(ope, sync); nothing in this
+// package builds it from grammar text. On failure inside ope, it records the failure into c.errors instead of
+// propagating it, then skips forward to the next position where sync
+// matches and reports success, so the rest of the input still gets parsed
+// and a single bad construct doesn't abort the whole parse.
+type errorRecovery struct {
+	opeBase
+	ope  operator
+	sync operator
+}
+
+func (o *errorRecovery) parseCore(s string, p int, v *Values, c *context, d Any) (l int, err error) {
+	chv := c.push()
+	l, err = o.ope.parse(s, p, chv, c, d)
+	c.pop()
+
+	if err == nil {
+		v.Vs = append(v.Vs, chv.Vs...)
+		v.Ts = append(v.Ts, chv.Ts...)
+		return l, nil
+	}
+
+	c.errors = append(c.errors, flattenError(err)...)
+
+	skip := 0
+	for p+skip < len(s) {
+		if syncLen, e := o.sync.parse(s, p+skip, &Values{}, c, d); e == nil {
+			skip += syncLen
+			return skip, nil
+		}
+		skip++
+	}
+
+	// No synchronization point was found before the end of input: skip to
+	// EOF rather than past it.
+	return len(s) - p, nil
+}
+
+func (o *errorRecovery) accept(v visitor) {
+	v.visitErrorRecovery(o)
+}
+
+// flattenError collects the OperatorErrors out of err, descending into any
+// SequenceError so a single recovered failure can report all of the
+// alternatives that were tried at that position.
+func flattenError(err error) []OperatorError {
+	switch e := err.(type) {
+	case OperatorError:
+		return []OperatorError{e}
+	case SequenceError:
+		var out []OperatorError
+		for _, sub := range e.Errs {
+			out = append(out, flattenError(sub)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
 // User
 type user struct {
 	opeBase
@@ -631,11 +884,67 @@ type reference struct {
 }
 
 func (o *reference) parseCore(s string, p int, v *Values, c *context, d Any) (l int, err error) {
+	if c.buildAST && o.rule != nil {
+		return o.parseCoreWithAST(s, p, v, c, d)
+	}
+	return o.parseCoreInner(s, p, v, c, d)
+}
+
+// parseCoreWithAST wraps parseCoreInner so that, when Parser.ParseAST is in
+// use, every reference to a named rule gathers a Node carrying the rule
+// name, source position, matched text and the child nodes produced by its
+// own nested named references. A rule annotated <no-ast> in the grammar
+// still parses normally but is not wrapped, so its children are flattened
+// straight into the parent instead.
+func (o *reference) parseCoreWithAST(s string, p int, v *Values, c *context, d Any) (l int, err error) {
+	c.astStack = append(c.astStack, nil)
+
+	l, err = o.parseCoreInner(s, p, v, c, d)
+
+	children := c.astStack[len(c.astStack)-1]
+	c.astStack = c.astStack[:len(c.astStack)-1]
+
+	if err != nil {
+		return
+	}
+
+	if o.rule.NoAST {
+		if n := len(c.astStack); n > 0 {
+			c.astStack[n-1] = append(c.astStack[n-1], children...)
+		}
+		return
+	}
+
+	line, col := lineInfo(s, p)
+	node := &astNode{
+		rule:     o.name,
+		line:     line,
+		col:      col,
+		text:     s[p : p+l],
+		children: children,
+	}
+	if n := len(c.astStack); n > 0 {
+		c.astStack[n-1] = append(c.astStack[n-1], node)
+	}
+	return
+}
+
+func (o *reference) parseCoreInner(s string, p int, v *Values, c *context, d Any) (l int, err error) {
 	if o.rule != nil {
 		// Reference rule
 		if o.rule.Parameters == nil {
 			// Definition
-			l, err = o.rule.parse(s, p, v, c, d)
+			body := func(v *Values, c *context) (int, error) {
+				if c.memo != nil && o.rule.Pure && !o.seedGrowing(c, p) {
+					return o.parseMemoized(memoKey{ruleID: o.rule.ID, pos: p}, s, p, v, c, d)
+				}
+				return o.rule.parse(s, p, v, c, d)
+			}
+			if c.leftRecursion {
+				l, err = parseWithSeedGrowing(o.rule.ID, p, v, c, body)
+			} else {
+				l, err = body(v, c)
+			}
 		} else {
 			// Macro
 			vis := &findReference{
@@ -651,7 +960,17 @@ func (o *reference) parseCore(s string, p int, v *Values, c *context, d Any) (l
 			}
 
 			c.pushArgs(args)
-			l, err = o.rule.parse(s, p, v, c, d)
+			body := func(v *Values, c *context) (int, error) {
+				if c.memo != nil && o.rule.Pure && !o.seedGrowing(c, p) {
+					return o.parseMemoized(memoKey{ruleID: o.rule.ID, pos: p, argsKey: argsHash(args)}, s, p, v, c, d)
+				}
+				return o.rule.parse(s, p, v, c, d)
+			}
+			if c.leftRecursion {
+				l, err = parseWithSeedGrowing(o.rule.ID, p, v, c, body)
+			} else {
+				l, err = body(v, c)
+			}
 			c.popArgs()
 		}
 	} else {
@@ -662,6 +981,58 @@ func (o *reference) parseCore(s string, p int, v *Values, c *context, d Any) (l
 	return
 }
 
+// seedGrowing reports whether parseWithSeedGrowing has a head installed for
+// o.rule at p. That head is in place for the whole of every application
+// routed through parseWithSeedGrowing, first pass included, so whenever
+// left recursion is enabled the packrat memo is neither read nor written
+// for rule references. parseWithSeedGrowing calls body again on every
+// growth iteration expecting a fresh parse, so packrat memoizing that same
+// (ruleID, pos) here would just hand back the first, shortest iteration's
+// cached result forever and the seed would never grow past it.
+func (o *reference) seedGrowing(c *context, p int) bool {
+	return c.leftRecursion && c.heads[lrKey{o.rule.ID, p}] != nil
+}
+
+// parseMemoized looks up key in the packrat memo table, filling in v.Vs/v.Ts
+// from a cached entry on a hit. On a miss it parses the rule body, stores
+// the result, and returns it. Rules with side-effectful Actions opt out via
+// Rule.Pure == false, since memoizing them would skip the side effect on a
+// cache hit.
+func (o *reference) parseMemoized(key memoKey, s string, p int, v *Values, c *context, d Any) (int, error) {
+	if ent, ok := c.memo[key]; ok {
+		if ent.err != nil {
+			return 0, ent.err
+		}
+		v.Vs = append(v.Vs, ent.values...)
+		v.Ts = append(v.Ts, ent.tokens...)
+		return ent.length, nil
+	}
+
+	saveVsLen := len(v.Vs)
+	saveTsLen := len(v.Ts)
+	l, err := o.rule.parse(s, p, v, c, d)
+
+	ent := memoEntry{length: l, err: err}
+	if err == nil {
+		ent.values = append(ent.values, v.Vs[saveVsLen:]...)
+		ent.tokens = append(ent.tokens, v.Ts[saveTsLen:]...)
+	}
+	c.memo[key] = ent
+
+	return l, err
+}
+
+// argsHash builds a stable key for a macro's bound argument operators, so
+// two calls to the same macro with different arguments don't collide in the
+// memo table.
+func argsHash(args []operator) string {
+	var sb strings.Builder
+	for _, a := range args {
+		fmt.Fprintf(&sb, "%p;", a)
+	}
+	return sb.String()
+}
+
 func (o *reference) accept(v visitor) {
 	v.visitReference(o)
 }
@@ -728,13 +1099,19 @@ func Npd(ope operator) operator {
 	o.derived = o
 	return o
 }
+func Cut() operator {
+	o := &cut{}
+	o.derived = o
+	return o
+}
 func Lit(lit string) operator {
 	o := &literalString{lit: lit}
 	o.derived = o
 	return o
 }
 func Cls(chars string) operator {
-	o := &characterClass{chars: chars}
+	ranges, classes, negClasses := parseCharClass(chars)
+	o := &characterClass{chars: chars, ranges: ranges, classes: classes, negClasses: negClasses}
 	o.derived = o
 	return o
 }
@@ -753,6 +1130,11 @@ func Ign(ope operator) operator {
 	o.derived = o
 	return o
 }
+func Rec(ope operator, sync operator) operator {
+	o := &errorRecovery{ope: ope, sync: sync}
+	o.derived = o
+	return o
+}
 func Usr(fn func(s string, p int, v *Values, d Any) (int, error)) operator {
 	o := &user{fn: fn}
 	o.derived = o
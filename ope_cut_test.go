@@ -0,0 +1,39 @@
+package peg
+
+import "testing"
+
+func TestCutCommitsChoice(t *testing.T) {
+	// Cho(Seq(Lit("if"), Cut(), Lit("true")), Lit("ifx")) should report the
+	// "if" branch's failure rather than falling through to "ifx", since the
+	// cut commits once "if" has matched.
+	ope := Cho(
+		Seq(Lit("if"), Cut(), Lit("true")),
+		Lit("ifx"),
+	)
+
+	c := &context{}
+	v := &Values{}
+	_, err := ope.parseCore("ifx", 0, v, c, nil)
+	if err == nil {
+		t.Fatalf("[Cut] input:%q want error (committed to \"if\" branch), got none", "ifx")
+	}
+
+	seqErr, ok := err.(SequenceError)
+	if !ok || len(seqErr.Errs) != 1 {
+		t.Fatalf("[Cut] input:%q want a single committed failure, got:%v", "ifx", err)
+	}
+}
+
+func TestCutDoesNotAffectMatchingAlternative(t *testing.T) {
+	ope := Cho(
+		Seq(Lit("if"), Cut(), Lit("true")),
+		Lit("ifx"),
+	)
+
+	c := &context{}
+	v := &Values{}
+	l, err := ope.parseCore("iftrue", 0, v, c, nil)
+	if err != nil || l != len("iftrue") {
+		t.Errorf("[Cut] input:%q want:%d got:%d err:%v", "iftrue", len("iftrue"), l, err)
+	}
+}
@@ -0,0 +1,83 @@
+package peg
+
+import "testing"
+
+func TestCharacterClassUTF8(t *testing.T) {
+	ope := Cls("α-ωあ-ん一-鿿")
+	cases := Cases{
+		{"", -1},
+		{"α", 2},
+		{"ω", 2},
+		{"β", 2},
+		{"あ", 3},
+		{"ん", 3},
+		{"漢", 3},
+		{"a", -1},
+		{" ", -1},
+	}
+	for _, cs := range cases {
+		c := &context{}
+		v := &Values{}
+		l, err := ope.parseCore(cs.input, 0, v, c, nil)
+		want := cs.want
+		if want < 0 {
+			if err == nil {
+				t.Errorf("[CharacterClassUTF8] input:%q want error, got l:%d", cs.input, l)
+			}
+			continue
+		}
+		if err != nil || l != want {
+			t.Errorf("[CharacterClassUTF8] input:%q want:%d got:%d err:%v", cs.input, want, l, err)
+		}
+	}
+}
+
+func TestCharacterClassCategory(t *testing.T) {
+	letters := Cls(`\p{L}`)
+	if l, err := letters.parseCore("漢", 0, &Values{}, &context{}, nil); err != nil || l != 3 {
+		t.Errorf("[CharacterClassCategory] \\p{L} against \"漢\" want:3 got:%d err:%v", l, err)
+	}
+	if _, err := letters.parseCore("7", 0, &Values{}, &context{}, nil); err == nil {
+		t.Errorf("[CharacterClassCategory] \\p{L} against \"7\" want error, got none")
+	}
+
+	notNumbers := Cls(`\P{N}`)
+	if l, err := notNumbers.parseCore("x", 0, &Values{}, &context{}, nil); err != nil || l != 1 {
+		t.Errorf("[CharacterClassCategory] \\P{N} against \"x\" want:1 got:%d err:%v", l, err)
+	}
+	if _, err := notNumbers.parseCore("7", 0, &Values{}, &context{}, nil); err == nil {
+		t.Errorf("[CharacterClassCategory] \\P{N} against \"7\" want error, got none")
+	}
+
+	han := Cls(`\p{Han}`)
+	if l, err := han.parseCore("語", 0, &Values{}, &context{}, nil); err != nil || l != 3 {
+		t.Errorf("[CharacterClassCategory] \\p{Han} against \"語\" want:3 got:%d err:%v", l, err)
+	}
+	if _, err := han.parseCore("a", 0, &Values{}, &context{}, nil); err == nil {
+		t.Errorf("[CharacterClassCategory] \\p{Han} against \"a\" want error, got none")
+	}
+}
+
+func TestAnyCharacterUTF8(t *testing.T) {
+	ope := Dot()
+	cases := Cases{
+		{"", -1},
+		{"a", 1},
+		{"あ", 3},
+		{"漢", 3},
+	}
+	for _, cs := range cases {
+		c := &context{}
+		v := &Values{}
+		l, err := ope.parseCore(cs.input, 0, v, c, nil)
+		if cs.want < 0 {
+			if err == nil {
+				t.Errorf("[AnyCharacterUTF8] input:%q want error, got l:%d", cs.input, l)
+			}
+			continue
+		}
+		if err != nil || l != cs.want {
+			t.Errorf("[AnyCharacterUTF8] input:%q want:%d got:%d err:%v", cs.input, cs.want, l, err)
+		}
+	}
+}
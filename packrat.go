@@ -0,0 +1,16 @@
+package peg
+
+// EnablePackrat turns packrat memoization on or off for subsequent calls to
+// Parse. When enabled, Parser.Parse allocates a fresh context.memo map for
+// each top-level parse, so memo entries never leak between unrelated
+// inputs. Memoization is skipped for any rule whose Action has side effects
+// (Rule.Pure == false), since a cache hit would otherwise replay the cached
+// result without re-running the action.
+//
+// Combined with the Cut() operator, a committed-but-failed alternative is
+// memoized as a hard failure, so repeated references to the same rule at
+// the same position don't re-walk alternatives that are already known to be
+// dead ends.
+func (p *Parser) EnablePackrat(enable bool) {
+	p.packrat = enable
+}
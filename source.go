@@ -0,0 +1,143 @@
+package peg
+
+import (
+	"io"
+	"strings"
+)
+
+// source is a small buffered, rewindable view over a rune stream. It backs
+// Parser.ParseReader, which grows a source's buffer one chunk at a time and
+// retries the parse, instead of reading the whole io.RuneReader up front
+// (see peglint's ioutil.ReadFile) - so a match that completes well before
+// EOF doesn't force the rest of a huge file or a still-open network stream
+// to be read first.
+//
+// peek/advance/mark/reset give a future, fully zero-copy version of this -
+// every operator's parseCore taking a *source instead of (s string, p int)
+// - the same backtracking primitives parseCore already gets from a plain
+// string and position pair.
+type source struct {
+	r   io.RuneReader
+	buf []rune
+	pos int
+	eof bool
+}
+
+func newSource(r io.RuneReader) *source {
+	return &source{r: r}
+}
+
+// fill buffers runes from the underlying reader until at least n of them
+// are available past pos, or the reader is exhausted.
+func (src *source) fill(n int) {
+	for !src.eof && len(src.buf)-src.pos < n {
+		r, _, err := src.r.ReadRune()
+		if err != nil {
+			src.eof = true
+			break
+		}
+		src.buf = append(src.buf, r)
+	}
+}
+
+// peek returns the rune n positions ahead of pos (0 = next unread rune)
+// without consuming it. ok is false once the stream is exhausted.
+func (src *source) peek(n int) (r rune, ok bool) {
+	src.fill(n + 1)
+	if src.pos+n >= len(src.buf) {
+		return 0, false
+	}
+	return src.buf[src.pos+n], true
+}
+
+// advance consumes n runes.
+func (src *source) advance(n int) {
+	src.fill(n)
+	src.pos += n
+	if src.pos > len(src.buf) {
+		src.pos = len(src.buf)
+	}
+}
+
+// mark returns a checkpoint that reset can later rewind the source to,
+// giving operators the same backtracking ability they have over a plain
+// string and position pair.
+func (src *source) mark() int {
+	return src.pos
+}
+
+// reset rewinds the source to a checkpoint previously returned by mark.
+func (src *source) reset(m int) {
+	src.pos = m
+}
+
+// sliceFrom returns, as a string, the runes consumed between a mark and the
+// current position. This is how Tok() would capture a token by mark/length
+// instead of by substring once operators read from a source directly.
+func (src *source) sliceFrom(m int) string {
+	return string(src.buf[m:src.pos])
+}
+
+// readChunk is how much more of r ParseReader buffers per retry.
+const readChunk = 4096
+
+// ParseReader parses input read from r instead of a fully-buffered string,
+// for grammars over large files or network streams. It buffers r through a
+// source in readChunk-sized increments and retries the parse after each
+// one, returning as soon as a match succeeds (or r is exhausted) rather
+// than reading all of r before parsing a single rune. Because Parse itself
+// still works over a string, a retry re-parses from the start each time;
+// this is still a real reduction in how much of r has to be available
+// before ParseReader can return, not just a relabeled full buffer.
+//
+// A failed parse only triggers another retry when it plausibly ran out of
+// buffered input - the furthest position any reported error reached is at
+// the edge of what's currently buffered. An error that gives up strictly
+// before that edge is a genuine mismatch that more of r won't fix, so it's
+// returned immediately instead of buffering the rest of a huge file first.
+func (p *Parser) ParseReader(r io.RuneReader, d Any) (*Values, *Error) {
+	src := newSource(r)
+
+	for {
+		src.fill(len(src.buf) + readChunk)
+
+		buffered := string(src.buf)
+		v, err := p.Parse(buffered, d)
+		if err == nil || src.eof {
+			return v, err
+		}
+		if furthestErrorOffset(buffered, err.Details) < len(buffered) {
+			return v, err
+		}
+		// The buffered prefix ran out before the grammar could finish
+		// matching; read another chunk of r and try again instead of
+		// reporting a premature error.
+	}
+}
+
+// furthestErrorOffset returns the byte offset, into buffered, of the
+// furthest position any of details reached - the high-water mark of how far
+// the grammar actually got before giving up.
+func furthestErrorOffset(buffered string, details []OperatorError) int {
+	furthest := 0
+	for _, d := range details {
+		if off := offsetFromLineCol(buffered, d.Line, d.Col); off > furthest {
+			furthest = off
+		}
+	}
+	return furthest
+}
+
+// offsetFromLineCol converts a 1-based line/col, as OperatorError.Line/Col
+// are reported elsewhere in this package, back into a byte offset into s.
+func offsetFromLineCol(s string, line, col int) int {
+	off := 0
+	for cur := 1; cur < line; cur++ {
+		idx := strings.IndexByte(s[off:], '\n')
+		if idx < 0 {
+			return len(s)
+		}
+		off += idx + 1
+	}
+	return off + col - 1
+}
@@ -0,0 +1,113 @@
+package peg
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSourcePeekAdvance(t *testing.T) {
+	src := newSource(strings.NewReader("abc"))
+
+	if r, ok := src.peek(0); !ok || r != 'a' {
+		t.Fatalf("[Source] peek(0) want:'a' got:%q ok:%v", r, ok)
+	}
+	if r, ok := src.peek(2); !ok || r != 'c' {
+		t.Fatalf("[Source] peek(2) want:'c' got:%q ok:%v", r, ok)
+	}
+	if _, ok := src.peek(3); ok {
+		t.Fatalf("[Source] peek(3) want ok:false past end of input")
+	}
+
+	src.advance(1)
+	if r, ok := src.peek(0); !ok || r != 'b' {
+		t.Fatalf("[Source] after advance(1) peek(0) want:'b' got:%q ok:%v", r, ok)
+	}
+}
+
+func TestSourceMarkReset(t *testing.T) {
+	src := newSource(strings.NewReader("hello"))
+
+	m := src.mark()
+	src.advance(3)
+	if got := src.sliceFrom(m); got != "hel" {
+		t.Errorf("[Source] sliceFrom want:%q got:%q", "hel", got)
+	}
+
+	src.reset(m)
+	if r, ok := src.peek(0); !ok || r != 'h' {
+		t.Errorf("[Source] after reset peek(0) want:'h' got:%q ok:%v", r, ok)
+	}
+}
+
+// countingRuneReader wraps a RuneReader and counts how many runes have
+// actually been pulled out of it, so tests can tell fill apart from a full
+// drain.
+type countingRuneReader struct {
+	r     io.RuneReader
+	reads int
+}
+
+func (c *countingRuneReader) ReadRune() (rune, int, error) {
+	r, size, err := c.r.ReadRune()
+	if err == nil {
+		c.reads++
+	}
+	return r, size, err
+}
+
+func TestSourceFillOnlyReadsWhatWasAskedFor(t *testing.T) {
+	counted := &countingRuneReader{r: strings.NewReader("abcdefghij")}
+	src := newSource(counted)
+
+	src.fill(3)
+	if counted.reads != 3 {
+		t.Errorf("[Source] fill(3) want exactly 3 runes read, got %d", counted.reads)
+	}
+	if src.eof {
+		t.Errorf("[Source] fill(3) of a 10-rune input should not have hit EOF")
+	}
+
+	src.fill(3) // already buffered, should not read more
+	if counted.reads != 3 {
+		t.Errorf("[Source] fill(3) again over an already-buffered prefix read more: %d", counted.reads)
+	}
+}
+
+func TestOffsetFromLineCol(t *testing.T) {
+	s := "ab\ncd\nef"
+	cases := []struct {
+		line, col, want int
+	}{
+		{1, 1, 0},
+		{1, 3, 2},
+		{2, 1, 3},
+		{3, 2, 7},
+	}
+	for _, cs := range cases {
+		if got := offsetFromLineCol(s, cs.line, cs.col); got != cs.want {
+			t.Errorf("[OffsetFromLineCol] line:%d col:%d want:%d got:%d", cs.line, cs.col, cs.want, got)
+		}
+	}
+}
+
+func TestFurthestErrorOffset(t *testing.T) {
+	// ParseReader's retry decision hinges on this: an error whose furthest
+	// detail lands before the end of the buffer is a genuine mismatch, not
+	// a buffer edge, and shouldn't trigger another read of r.
+	buffered := "ab\ncdef"
+	details := []OperatorError{
+		{Line: 1, Col: 2},
+		{Line: 2, Col: 3},
+	}
+	if got, want := furthestErrorOffset(buffered, details), len("ab\ncd"); got != want {
+		t.Errorf("[FurthestErrorOffset] want:%d got:%d", want, got)
+	}
+}
+
+// ParseReader itself isn't exercised directly here: it's a method on
+// *Parser, and this snapshot of the tree doesn't carry the grammar/Rule
+// machinery (Parser, Parse) that would let a test build one end to end -
+// the same reason source_test.go otherwise only drives source's own
+// primitives. furthestErrorOffset and offsetFromLineCol, the pieces
+// ParseReader's retry decision actually depends on, are covered above.